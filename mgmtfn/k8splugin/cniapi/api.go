@@ -46,3 +46,21 @@ type RspAddPod struct {
 	ErrMsg     string `json:"errmsg,omitempty"`
 	ErrInfo    string `json:"errinfo,omitempty"`
 }
+
+// LatencyBucket is a cumulative histogram bucket, holding the number of
+// samples that took at most LeMs milliseconds
+type LatencyBucket struct {
+	LeMs  float64 `json:"le_ms"`
+	Count uint64  `json:"count"`
+}
+
+// LatencyHistogram holds the latency distribution of one phase of a cni operation
+type LatencyHistogram struct {
+	Count   uint64          `json:"count"`
+	SumMs   float64         `json:"sum_ms"`
+	MaxMs   float64         `json:"max_ms"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// RspLatency contains the latency histograms keyed by operation and phase
+type RspLatency map[string]map[string]LatencyHistogram
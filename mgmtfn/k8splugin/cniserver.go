@@ -101,6 +101,14 @@ func InitKubServiceWatch(np *plugin.NetPlugin) {
 
 	svcCh := make(chan SvcWatchResp, 1)
 	epCh := make(chan EpWatchResp, 1)
+
+	// service updates are not batched, only retried. They are applied on
+	// their own worker, so a provider update may be applied before the spec
+	// it belongs to. That is fine: NodeSvcProxy and ofnet both keep providers
+	// apart from specs and program a service once they have both.
+	svcQueue := k8sutils.NewEventQueue(0)
+	go processSvcEvents(np, svcQueue)
+
 	go func() {
 		for {
 			select {
@@ -117,11 +125,8 @@ func InitKubServiceWatch(np *plugin.NetPlugin) {
 					watchClient.WatchServices(svcCh)
 					break
 
-				case "DELETED":
-					np.DelSvcSpec(svcEvent.svcName, &svcEvent.svcSpec)
-					break
 				default:
-					np.AddSvcSpec(svcEvent.svcName, &svcEvent.svcSpec)
+					svcQueue.Add(svcEvent.svcName, svcEvent)
 				}
 			case epEvent := <-epCh:
 				switch epEvent.opcode {
@@ -147,6 +152,34 @@ func InitKubServiceWatch(np *plugin.NetPlugin) {
 	watchClient.WatchSvcEps(epCh)
 }
 
+// processSvcEvents applies queued service updates one at a time, retrying
+// failed ones with backoff until they succeed or a newer update replaces them
+func processSvcEvents(np *plugin.NetPlugin, svcQueue *k8sutils.EventQueue) {
+	for {
+		ev := svcQueue.Get()
+		svcEvent := ev.Obj.(SvcWatchResp)
+
+		var err error
+		if svcEvent.opcode == "DELETED" {
+			err = np.DelSvcSpec(svcEvent.svcName, &svcEvent.svcSpec)
+		} else {
+			err = np.AddSvcSpec(svcEvent.svcName, &svcEvent.svcSpec)
+		}
+
+		if err != nil {
+			if delay, ok := svcQueue.Requeue(ev); ok {
+				log.Warnf("svcWatch : failed to apply %s for service %s, retrying in %v. Err: %v",
+					svcEvent.opcode, svcEvent.svcName, delay, err)
+			} else {
+				log.Warnf("svcWatch : failed to apply %s for service %s, superseded by a newer update. Err: %v",
+					svcEvent.opcode, svcEvent.svcName, err)
+			}
+			continue
+		}
+		svcQueue.Forget(ev)
+	}
+}
+
 // InitCNIServer initializes the k8s cni server
 func InitCNIServer(netplugin *plugin.NetPlugin) error {
 
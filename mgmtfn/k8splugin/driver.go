@@ -146,7 +146,9 @@ func createEP(req *epSpec) (*epAttr, error) {
 	}
 
 	var mresp master.CreateEndpointResponse
+	start := time.Now()
 	err = cluster.MasterPostReq("/plugin/createEndpoint", &mreq, &mresp)
+	observeLatency(addPodOp, phaseMaster, start)
 	if err != nil {
 		epCleanUp(req)
 		return nil, err
@@ -155,7 +157,9 @@ func createEP(req *epSpec) (*epAttr, error) {
 	log.Infof("Got endpoint create resp from master: %+v", mresp)
 
 	// Ask netplugin to create the endpoint
+	start = time.Now()
 	err = netPlugin.CreateEndpoint(netID + "-" + req.EndpointID)
+	observeLatency(addPodOp, phaseEndpoint, start)
 	if err != nil {
 		log.Errorf("Endpoint creation failed. Error: %s", err)
 		epCleanUp(req)
@@ -384,6 +388,7 @@ func addPod(r *http.Request) (interface{}, error) {
 	resp := cniapi.RspAddPod{}
 
 	logEvent("add pod")
+	defer observeLatency(addPodOp, phaseTotal, time.Now())
 
 	content, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -397,7 +402,9 @@ func addPod(r *http.Request) (interface{}, error) {
 	}
 
 	// Get labels from the kube api server
+	start := time.Now()
 	epReq, err := getEPSpec(&pInfo)
+	observeLatency(addPodOp, phaseLabels, start)
 	if err != nil {
 		log.Errorf("Error getting labels. Err: %v", err)
 		setErrorResp(&resp, "Error getting labels", err)
@@ -412,6 +419,7 @@ func addPod(r *http.Request) (interface{}, error) {
 	}
 
 	// convert netns to pid that netlink needs
+	start = time.Now()
 	pid, err := nsToPID(pInfo.NwNameSpace)
 	if err != nil {
 		log.Errorf("Error moving to netns. Err: %v", err)
@@ -421,6 +429,7 @@ func addPod(r *http.Request) (interface{}, error) {
 
 	// Set interface attributes for the new port
 	err = setIfAttrs(pid, ep.PortName, ep.IPAddress, pInfo.IntfName)
+	observeLatency(addPodOp, phaseInterface, start)
 	if err != nil {
 		log.Errorf("Error setting interface attributes. Err: %v", err)
 		setErrorResp(&resp, "Error setting interface attributes", err)
@@ -431,6 +440,7 @@ func addPod(r *http.Request) (interface{}, error) {
 	gwIntf := pInfo.IntfName
 	gw := ep.Gateway
 	if gw == "" {
+		start = time.Now()
 		hostIf := netutils.GetHostIntfName(ep.PortName)
 		hostIP, err := netPlugin.CreateHostAccPort(hostIf, ep.IPAddress)
 		if err != nil {
@@ -451,11 +461,13 @@ func addPod(r *http.Request) (interface{}, error) {
 				}
 			}
 		}
-
+		observeLatency(addPodOp, phaseHostAccess, start)
 	}
 
 	// Set default gateway
+	start = time.Now()
 	err = setDefGw(pid, gw, gwIntf)
+	observeLatency(addPodOp, phaseGateway, start)
 	if err != nil {
 		log.Errorf("Error setting default gateway. Err: %v", err)
 		setErrorResp(&resp, "Error setting default gateway", err)
@@ -474,6 +486,7 @@ func deletePod(r *http.Request) (interface{}, error) {
 	resp := cniapi.RspAddPod{}
 
 	logEvent("del pod")
+	defer observeLatency(delPodOp, phaseTotal, time.Now())
 
 	content, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -487,15 +500,23 @@ func deletePod(r *http.Request) (interface{}, error) {
 	}
 
 	// Get labels from the kube api server
+	start := time.Now()
 	epReq, err := getEPSpec(&pInfo)
+	observeLatency(delPodOp, phaseLabels, start)
 	if err != nil {
 		log.Errorf("Error getting labels. Err: %v", err)
 		setErrorResp(&resp, "Error getting labels", err)
 		return resp, err
 	}
 
+	start = time.Now()
 	netPlugin.DeleteHostAccPort(epReq.EndpointID)
-	if err = epCleanUp(epReq); err != nil {
+	observeLatency(delPodOp, phaseHostAccess, start)
+
+	start = time.Now()
+	err = epCleanUp(epReq)
+	observeLatency(delPodOp, phaseCleanup, start)
+	if err != nil {
 		log.Errorf("failed to delete pod, error: %s", err)
 	}
 	resp.Result = 0
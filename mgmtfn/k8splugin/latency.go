@@ -0,0 +1,144 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8splugin
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/contiv/netplugin/mgmtfn/k8splugin/cniapi"
+)
+
+// cni operations whose phases are timed
+const (
+	addPodOp = "AddPod"
+	delPodOp = "DelPod"
+)
+
+// phases of the cni operations
+const (
+	phaseTotal      = "total"
+	phaseLabels     = "labels"      // pod label lookup from the k8s api server
+	phaseMaster     = "master"      // endpoint create on netmaster: admission, quota and address allocation
+	phaseEndpoint   = "endpoint"    // endpoint create in the network driver
+	phaseInterface  = "interface"   // moving and configuring the container interface
+	phaseHostAccess = "host-access" // host access port setup/teardown
+	phaseGateway    = "gateway"     // default route in the container
+	phaseCleanup    = "cleanup"     // endpoint delete in the network driver and netmaster
+)
+
+// latencyBuckets are the upper bounds of the histogram buckets, in milliseconds
+var latencyBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// latencyHistogram accumulates durations into fixed buckets
+type latencyHistogram struct {
+	buckets []uint64 // last entry counts samples above the largest bound
+	count   uint64
+	sumMs   float64
+	maxMs   float64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{buckets: make([]uint64, len(latencyBuckets)+1)}
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+
+	idx := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if ms <= bound {
+			idx = i
+			break
+		}
+	}
+
+	h.buckets[idx]++
+	h.count++
+	h.sumMs += ms
+	if ms > h.maxMs {
+		h.maxMs = ms
+	}
+}
+
+func (h *latencyHistogram) snapshot() cniapi.LatencyHistogram {
+	snap := cniapi.LatencyHistogram{
+		Count: h.count,
+		SumMs: h.sumMs,
+		MaxMs: h.maxMs,
+	}
+
+	// buckets are reported cumulatively, each holds all samples <= its bound
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += h.buckets[i]
+		snap.Buckets = append(snap.Buckets, cniapi.LatencyBucket{LeMs: bound, Count: cumulative})
+	}
+
+	return snap
+}
+
+// cniLatency holds the histograms of all operation phases seen so far
+var cniLatency = struct {
+	sync.Mutex
+	hist map[string]map[string]*latencyHistogram // op -> phase -> histogram
+}{hist: make(map[string]map[string]*latencyHistogram)}
+
+// observeLatency records the time elapsed since start for a phase of an
+// operation. It is meant to be used as
+// `defer observeLatency(op, phase, time.Now())` or called right after the phase.
+func observeLatency(op, phase string, start time.Time) {
+	d := time.Since(start)
+
+	cniLatency.Lock()
+	defer cniLatency.Unlock()
+
+	phases, ok := cniLatency.hist[op]
+	if !ok {
+		phases = make(map[string]*latencyHistogram)
+		cniLatency.hist[op] = phases
+	}
+
+	h, ok := phases[phase]
+	if !ok {
+		h = newLatencyHistogram()
+		phases[phase] = h
+	}
+
+	h.observe(d)
+}
+
+// GetLatency returns the latency histograms of cni operations as json
+func GetLatency() ([]byte, error) {
+	return json.Marshal(latencySnapshot())
+}
+
+func latencySnapshot() cniapi.RspLatency {
+	resp := cniapi.RspLatency{}
+
+	cniLatency.Lock()
+	defer cniLatency.Unlock()
+
+	for op, phases := range cniLatency.hist {
+		resp[op] = make(map[string]cniapi.LatencyHistogram)
+		for phase, h := range phases {
+			resp[op][phase] = h.snapshot()
+		}
+	}
+
+	return resp
+}
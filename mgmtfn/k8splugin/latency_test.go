@@ -0,0 +1,61 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8splugin
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/contiv/check"
+	"github.com/contiv/netplugin/mgmtfn/k8splugin/cniapi"
+)
+
+type LatencySuite struct{}
+
+var _ = Suite(&LatencySuite{})
+
+func (s *LatencySuite) TestHistogramBuckets(c *C) {
+	h := newLatencyHistogram()
+	h.observe(500 * time.Microsecond)
+	h.observe(7 * time.Millisecond)
+	h.observe(10 * time.Millisecond)
+	h.observe(20 * time.Second)
+
+	snap := h.snapshot()
+	c.Assert(snap.Count, Equals, uint64(4))
+	c.Assert(snap.MaxMs, Equals, float64(20000))
+	c.Assert(len(snap.Buckets), Equals, len(latencyBuckets))
+
+	// cumulative counts: <=1ms, <=5ms, <=10ms ... the 20s sample is only in count
+	c.Assert(snap.Buckets[0], Equals, cniapi.LatencyBucket{LeMs: 1, Count: 1})
+	c.Assert(snap.Buckets[1], Equals, cniapi.LatencyBucket{LeMs: 5, Count: 1})
+	c.Assert(snap.Buckets[2], Equals, cniapi.LatencyBucket{LeMs: 10, Count: 3})
+	c.Assert(snap.Buckets[len(snap.Buckets)-1].Count, Equals, uint64(3))
+}
+
+func (s *LatencySuite) TestObserveLatency(c *C) {
+	observeLatency(addPodOp, phaseLabels, time.Now())
+	observeLatency(addPodOp, phaseLabels, time.Now())
+	observeLatency(delPodOp, phaseTotal, time.Now().Add(-time.Second))
+
+	buf, err := GetLatency()
+	c.Assert(err, IsNil)
+
+	lat := cniapi.RspLatency{}
+	c.Assert(json.Unmarshal(buf, &lat), IsNil)
+	c.Assert(lat[addPodOp][phaseLabels].Count >= 2, Equals, true)
+	c.Assert(lat[delPodOp][phaseTotal].MaxMs >= 1000, Equals, true)
+}
@@ -204,6 +204,25 @@ func (d *MasterDaemon) registerRoutes(router *mux.Router) {
 		w.Write(resp)
 	})
 
+	// endpoint quota usage
+	s.HandleFunc(fmt.Sprintf("/%s", master.GetQuotaRESTEndpoint), func(w http.ResponseWriter, r *http.Request) {
+		stats, err := master.GetEndpointQuotaStats()
+		if err != nil {
+			log.Errorf("Error getting endpoint quota. Err: %v", err)
+			http.Error(w, "Error getting endpoint quota", http.StatusInternalServerError)
+			return
+		}
+
+		resp, err := json.Marshal(stats)
+		if err != nil {
+			http.Error(w,
+				core.Errorf("marshaling json failed. Error: %s", err).Error(),
+				http.StatusInternalServerError)
+			return
+		}
+		w.Write(resp)
+	})
+
 	// services REST endpoints
 	// FIXME: we need to remove once service inspect is added
 	s.HandleFunc(fmt.Sprintf("/%s/%s", master.GetServiceRESTEndpoint, "{id}"),
@@ -310,6 +329,9 @@ func (d *MasterDaemon) becomeLeader() {
 	// set current state
 	d.currState = "leader"
 
+	// endpoints may have changed while another netmaster was leading
+	master.ResetEndpointQuotaUsage()
+
 	// Run the HTTP listener
 	go d.runLeader()
 }
@@ -22,10 +22,29 @@ const defaultEpgName = "ingress-group"
 const defaultPolicyName = "ingress-policy"
 const defaultRuleID = "1"
 
+// DefaultEventBatchWindow is how long to wait for more updates of the same
+// k8s object before acting on it
+const DefaultEventBatchWindow = 500 * time.Millisecond
+
+var eventBatchWindow = DefaultEventBatchWindow
+
+// SetEventBatchWindow sets how long k8s events for the same object are
+// coalesced before the object is processed. Zero disables batching.
+func SetEventBatchWindow(window time.Duration) {
+	eventBatchWindow = window
+}
+
 type k8sContext struct {
 	k8sClientSet *kubernetes.Clientset
 	contivClient *client.ContivClient
 	isLeader     func() bool
+	events       *k8sutils.EventQueue
+}
+
+// k8sEvent is a watch event waiting to be processed
+type k8sEvent struct {
+	opCode watch.EventType
+	obj    interface{}
 }
 
 var npLog *log.Entry
@@ -261,7 +280,7 @@ func (k8sNet *k8sContext) getIsolationPolicy(annotations map[string]string) stri
 	return "allow"
 }
 
-func (k8sNet *k8sContext) updateDefaultIngressPolicy(ns string, action string) {
+func (k8sNet *k8sContext) updateDefaultIngressPolicy(ns string, action string) error {
 	nwName := ns + "-" + defaultNetworkName
 	policyName := ns + "-" + defaultPolicyName
 	epgName := ns + "-" + defaultEpgName
@@ -270,26 +289,27 @@ func (k8sNet *k8sContext) updateDefaultIngressPolicy(ns string, action string) {
 
 	if err = k8sNet.createNetwork(nwName); err != nil {
 		npLog.Errorf("failed to update network %s, %s", nwName, err)
-		return
+		return err
 	}
 
 	if err = k8sNet.createPolicy(policyName); err != nil {
 		npLog.Errorf("failed to update policy %s, %s", policyName, err)
-		return
+		return err
 	}
 
 	if err = k8sNet.createEpg(nwName, epgName, policyName); err != nil {
 		npLog.Errorf("failed to update EPG %s, %s", epgName, err)
-		return
+		return err
 	}
 
 	if err = k8sNet.createRule(policyName, defaultRuleID, action); err != nil {
 		npLog.Errorf("failed to update default rule, %s", err)
-		return
+		return err
 	}
+	return nil
 }
 
-func (k8sNet *k8sContext) deleteDefaultIngressPolicy(ns string) {
+func (k8sNet *k8sContext) deleteDefaultIngressPolicy(ns string) error {
 	nwName := ns + "-" + defaultNetworkName
 	policyName := ns + "-" + defaultPolicyName
 	epgName := ns + "-" + defaultEpgName
@@ -298,22 +318,23 @@ func (k8sNet *k8sContext) deleteDefaultIngressPolicy(ns string) {
 
 	if err = k8sNet.deleteRule(policyName, defaultRuleID); err != nil {
 		npLog.Errorf("failed to delete default rule, %s", err)
-		return
+		return err
 	}
 
 	if err = k8sNet.deleteEpg(nwName, epgName, policyName); err != nil {
 		npLog.Errorf("failed to delete EPG %s, %s", epgName, err)
-		return
+		return err
 	}
 	if err = k8sNet.deletePolicy(policyName); err != nil {
 		npLog.Errorf("failed to delete policy %s, %s", policyName, err)
-		return
+		return err
 	}
+	return nil
 }
 
-func (k8sNet *k8sContext) processK8sNamespace(opCode watch.EventType, ns *v1.Namespace) {
+func (k8sNet *k8sContext) processK8sNamespace(opCode watch.EventType, ns *v1.Namespace) error {
 	if ns.Name == "kube-system" {
-		return
+		return nil
 	}
 
 	action := k8sNet.getIsolationPolicy(ns.Annotations)
@@ -323,18 +344,18 @@ func (k8sNet *k8sContext) processK8sNamespace(opCode watch.EventType, ns *v1.Nam
 	switch opCode {
 	case watch.Added, watch.Modified:
 		if action == "none" {
-			k8sNet.deleteDefaultIngressPolicy(ns.Name)
-		} else {
-			k8sNet.updateDefaultIngressPolicy(ns.Name, action)
+			return k8sNet.deleteDefaultIngressPolicy(ns.Name)
 		}
+		return k8sNet.updateDefaultIngressPolicy(ns.Name, action)
 	case watch.Deleted:
-		k8sNet.deleteDefaultIngressPolicy(ns.Name)
+		return k8sNet.deleteDefaultIngressPolicy(ns.Name)
 	}
+	return nil
 }
 
-func (k8sNet *k8sContext) processK8sNetworkPolicy(opCode watch.EventType, np *v1beta1.NetworkPolicy) {
+func (k8sNet *k8sContext) processK8sNetworkPolicy(opCode watch.EventType, np *v1beta1.NetworkPolicy) error {
 	if np.Namespace == "kube-system" { // not applicable for system namespace
-		return
+		return nil
 	}
 
 	npLog.Infof("process [%s] network policy  %+v", opCode, np)
@@ -343,19 +364,21 @@ func (k8sNet *k8sContext) processK8sNetworkPolicy(opCode watch.EventType, np *v1
 	case watch.Added, watch.Modified:
 	case watch.Deleted:
 	}
+	return nil
 }
 
-func (k8sNet *k8sContext) processK8sEvent(opCode watch.EventType, eventObj interface{}) {
+func (k8sNet *k8sContext) processK8sEvent(opCode watch.EventType, eventObj interface{}) error {
 	if k8sNet.isLeader() != true {
-		return
+		return nil
 	}
 	switch objType := eventObj.(type) {
 	case *v1.Namespace:
-		k8sNet.processK8sNamespace(opCode, objType)
+		return k8sNet.processK8sNamespace(opCode, objType)
 
 	case *v1beta1.NetworkPolicy:
-		k8sNet.processK8sNetworkPolicy(opCode, objType)
+		return k8sNet.processK8sNetworkPolicy(opCode, objType)
 	}
+	return nil
 }
 
 func (k8sNet *k8sContext) watchK8sEvents(errChan chan error) {
@@ -396,12 +419,44 @@ func (k8sNet *k8sContext) watchK8sEvents(errChan chan error) {
 		}
 
 		if event, ok := recVal.Interface().(watch.Event); ok {
-			k8sNet.processK8sEvent(event.Type, event.Object)
+			k8sNet.events.Add(k8sEventKey(event.Object),
+				k8sEvent{opCode: event.Type, obj: event.Object})
 		}
 		// ignore other events
 	}
 }
 
+// k8sEventKey returns the key under which events for the same object are batched
+func k8sEventKey(eventObj interface{}) string {
+	switch objType := eventObj.(type) {
+	case *v1.Namespace:
+		return "namespace/" + objType.Name
+	case *v1beta1.NetworkPolicy:
+		return "networkpolicy/" + objType.Namespace + "/" + objType.Name
+	}
+	return fmt.Sprintf("%T", eventObj)
+}
+
+// processK8sEvents handles batched events one at a time. Events that fail
+// are retried with backoff until they succeed or a newer event replaces them.
+func (k8sNet *k8sContext) processK8sEvents() {
+	for {
+		ev := k8sNet.events.Get()
+		kev := ev.Obj.(k8sEvent)
+		if err := k8sNet.processK8sEvent(kev.opCode, kev.obj); err != nil {
+			if delay, ok := k8sNet.events.Requeue(ev); ok {
+				npLog.Warnf("failed to process %s event for %s, retrying in %v: %s",
+					kev.opCode, ev.Key, delay, err)
+			} else {
+				npLog.Warnf("failed to process %s event for %s, superseded by a newer event: %s",
+					kev.opCode, ev.Key, err)
+			}
+			continue
+		}
+		k8sNet.events.Forget(ev)
+	}
+}
+
 // InitK8SServiceWatch monitor k8s services
 func InitK8SServiceWatch(listenURL string, isLeader func() bool) error {
 	npLog = log.WithField("k8s", "netpolicy")
@@ -421,8 +476,10 @@ func InitK8SServiceWatch(listenURL string, isLeader func() bool) error {
 		npLog.Fatalf("failed to init K8S client, %v", err)
 		return err
 	}
-	kubeNet := k8sContext{contivClient: contivClient, k8sClientSet: k8sClientSet, isLeader: isLeader}
+	kubeNet := k8sContext{contivClient: contivClient, k8sClientSet: k8sClientSet, isLeader: isLeader,
+		events: k8sutils.NewEventQueue(eventBatchWindow)}
 
+	go kubeNet.processK8sEvents()
 	go kubeNet.handleK8sEvents()
 	return nil
 }
@@ -26,6 +26,8 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/contiv/netplugin/netmaster/daemon"
 	"github.com/contiv/netplugin/netmaster/docknet"
+	"github.com/contiv/netplugin/netmaster/k8snetwork"
+	"github.com/contiv/netplugin/netmaster/master"
 	"github.com/contiv/netplugin/version"
 )
 
@@ -38,11 +40,18 @@ type cliOpts struct {
 	controlURL   string
 	clusterMode  string
 	version      bool
+	epWebhook    string
+	maxEpTenant  int
+	maxEpHost    int
+	k8sBatchWin  time.Duration
 }
 
 const (
 	defaultListenPort  = ":9999"
 	defaultControlPort = ":9999"
+
+	// endpoint creation is on the pod start path, so keep webhooks short
+	endpointWebhookTimeout = 5 * time.Second
 )
 
 var flagSet *flag.FlagSet
@@ -86,6 +95,22 @@ func parseOpts(opts *cliOpts) error {
 		"version",
 		false,
 		"prints current version")
+	flagSet.StringVar(&opts.epWebhook,
+		"endpoint-webhook",
+		"",
+		"URL of an external service to admit endpoint create/delete requests")
+	flagSet.IntVar(&opts.maxEpTenant,
+		"max-endpoints-per-tenant",
+		0,
+		"Maximum number of endpoints in a tenant, 0 for no limit")
+	flagSet.IntVar(&opts.maxEpHost,
+		"max-endpoints-per-host",
+		0,
+		"Maximum number of endpoints on a host, 0 for no limit")
+	flagSet.DurationVar(&opts.k8sBatchWin,
+		"k8s-event-batch-window",
+		networkpolicy.DefaultEventBatchWindow,
+		"Time to coalesce k8s namespace and network policy updates before applying them, 0 to disable")
 
 	return flagSet.Parse(os.Args[1:])
 }
@@ -156,6 +181,22 @@ func execOpts(opts *cliOpts) {
 	if opts.clusterMode == "docker" || opts.clusterMode == "swarm-mode" {
 		docknet.UpdatePluginName(opts.pluginName)
 	}
+
+	if opts.epWebhook != "" {
+		err = master.RegisterEndpointHook("webhook",
+			master.NewEndpointWebhook(opts.epWebhook, endpointWebhookTimeout))
+		if err != nil {
+			log.Fatalf("Failed to register endpoint webhook. Err: %v", err)
+		}
+	}
+
+	networkpolicy.SetEventBatchWindow(opts.k8sBatchWin)
+
+	if opts.maxEpTenant != 0 || opts.maxEpHost != 0 {
+		if err = master.SetEndpointQuota(opts.maxEpTenant, opts.maxEpHost); err != nil {
+			log.Fatalf("Failed to set endpoint quota. Err: %v", err)
+		}
+	}
 }
 
 func main() {
@@ -0,0 +1,193 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/contiv/netplugin/core"
+)
+
+// EndpointHook is invoked on endpoint requests received from netplugin,
+// before netmaster acts on them. CreateEndpoint may modify the request in
+// place, returning an error rejects the endpoint. DeleteEndpoint is given a
+// copy of the request and its errors are only logged, as the endpoint has
+// already been removed from the host by the time netmaster sees the delete.
+type EndpointHook interface {
+	CreateEndpoint(req *CreateEndpointRequest) error
+	DeleteEndpoint(req *DeleteEndpointRequest) error
+}
+
+type namedEndpointHook struct {
+	name string
+	hook EndpointHook
+}
+
+// endpoint hooks, run in the order they were registered
+var epHooks struct {
+	sync.Mutex
+	chain []namedEndpointHook
+}
+
+// RegisterEndpointHook appends a hook to the endpoint admission chain
+func RegisterEndpointHook(name string, hook EndpointHook) error {
+	epHooks.Lock()
+	defer epHooks.Unlock()
+
+	for _, h := range epHooks.chain {
+		if h.name == name {
+			return core.Errorf("endpoint hook %s is already registered", name)
+		}
+	}
+
+	epHooks.chain = append(epHooks.chain, namedEndpointHook{name: name, hook: hook})
+	log.Infof("Registered endpoint hook %s", name)
+	return nil
+}
+
+// UnregisterEndpointHook removes a hook from the endpoint admission chain
+func UnregisterEndpointHook(name string) {
+	epHooks.Lock()
+	defer epHooks.Unlock()
+
+	for i, h := range epHooks.chain {
+		if h.name == name {
+			epHooks.chain = append(epHooks.chain[:i], epHooks.chain[i+1:]...)
+			return
+		}
+	}
+}
+
+// getEndpointHooks returns a copy of the chain so hooks run without the lock
+func getEndpointHooks() []namedEndpointHook {
+	epHooks.Lock()
+	defer epHooks.Unlock()
+
+	return append([]namedEndpointHook(nil), epHooks.chain...)
+}
+
+// admitEndpointCreate runs the create request through the hook chain,
+// stopping at the first hook that rejects it
+func admitEndpointCreate(req *CreateEndpointRequest) error {
+	for _, h := range getEndpointHooks() {
+		if err := h.hook.CreateEndpoint(req); err != nil {
+			log.Errorf("Endpoint %s rejected by hook %s. Err: %v", req.EndpointID, h.name, err)
+			return core.Errorf("endpoint %s rejected by %s: %v", req.EndpointID, h.name, err)
+		}
+	}
+
+	return nil
+}
+
+// admitEndpointDelete runs the delete request through the hook chain. Each
+// hook sees its own copy, so none of them can change what gets deleted.
+func admitEndpointDelete(req DeleteEndpointRequest) {
+	for _, h := range getEndpointHooks() {
+		hookReq := req
+		if err := h.hook.DeleteEndpoint(&hookReq); err != nil {
+			log.Warnf("Endpoint hook %s failed on delete of %s. Err: %v", h.name, req.EndpointID, err)
+		}
+	}
+}
+
+// EndpointWebhookRequest is posted to an endpoint webhook
+type EndpointWebhookRequest struct {
+	Operation     string                 `json:"operation"` // "create" or "delete"
+	CreateRequest *CreateEndpointRequest `json:"createRequest,omitempty"`
+	DeleteRequest *DeleteEndpointRequest `json:"deleteRequest,omitempty"`
+}
+
+// EndpointWebhookResponse is expected back from an endpoint webhook. If the
+// webhook returns a create request, only its ServiceName, EPCommonName and
+// ConfigEP.ServiceName are applied. The fields identifying the endpoint are
+// kept from the original request, as netplugin keeps using them to refer to
+// the endpoint. Deletes cannot be modified: the endpoint is already gone from
+// the host, so netmaster always releases the one named in the original request.
+type EndpointWebhookResponse struct {
+	Allowed       bool                   `json:"allowed"`
+	Reason        string                 `json:"reason,omitempty"`
+	CreateRequest *CreateEndpointRequest `json:"createRequest,omitempty"`
+}
+
+// EndpointWebhook is an EndpointHook that defers the decision to an
+// external HTTP service
+type EndpointWebhook struct {
+	URL    string
+	client *http.Client
+}
+
+// NewEndpointWebhook returns a hook posting endpoint requests to url
+func NewEndpointWebhook(url string, timeout time.Duration) *EndpointWebhook {
+	return &EndpointWebhook{
+		URL:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// CreateEndpoint implements EndpointHook
+func (w *EndpointWebhook) CreateEndpoint(req *CreateEndpointRequest) error {
+	resp, err := w.post(&EndpointWebhookRequest{Operation: "create", CreateRequest: req})
+	if err != nil {
+		return err
+	}
+
+	if resp.CreateRequest != nil {
+		req.ServiceName = resp.CreateRequest.ServiceName
+		req.EPCommonName = resp.CreateRequest.EPCommonName
+		req.ConfigEP.ServiceName = resp.CreateRequest.ConfigEP.ServiceName
+	}
+
+	return nil
+}
+
+// DeleteEndpoint implements EndpointHook
+func (w *EndpointWebhook) DeleteEndpoint(req *DeleteEndpointRequest) error {
+	_, err := w.post(&EndpointWebhookRequest{Operation: "delete", DeleteRequest: req})
+	return err
+}
+
+func (w *EndpointWebhook) post(req *EndpointWebhookRequest) (*EndpointWebhookResponse, error) {
+	buf, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := w.client.Post(w.URL, "application/json", bytes.NewBuffer(buf))
+	if err != nil {
+		return nil, core.Errorf("webhook %s unreachable: %v", w.URL, err)
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return nil, core.Errorf("webhook %s returned %s", w.URL, r.Status)
+	}
+
+	resp := &EndpointWebhookResponse{}
+	if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+		return nil, core.Errorf("webhook %s returned invalid response: %v", w.URL, err)
+	}
+
+	if !resp.Allowed {
+		return nil, core.Errorf("%s", resp.Reason)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,163 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package master
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEndpointHook struct {
+	createFn func(req *CreateEndpointRequest) error
+	deletes  int
+}
+
+func (h *testEndpointHook) CreateEndpoint(req *CreateEndpointRequest) error {
+	return h.createFn(req)
+}
+
+func (h *testEndpointHook) DeleteEndpoint(req *DeleteEndpointRequest) error {
+	h.deletes++
+	req.EndpointID = "redirected"
+	return errors.New("delete hooks cannot veto")
+}
+
+func TestEndpointHookChain(t *testing.T) {
+	var order []string
+
+	rename := &testEndpointHook{createFn: func(req *CreateEndpointRequest) error {
+		order = append(order, "rename")
+		req.EPCommonName = "web-" + req.EPCommonName
+		return nil
+	}}
+	quota := &testEndpointHook{createFn: func(req *CreateEndpointRequest) error {
+		order = append(order, "quota")
+		if req.TenantName == "full" {
+			return errors.New("tenant is out of endpoints")
+		}
+		return nil
+	}}
+
+	if err := RegisterEndpointHook("rename", rename); err != nil {
+		t.Fatalf("Error registering hook. Err: %v", err)
+	}
+	defer UnregisterEndpointHook("rename")
+	if err := RegisterEndpointHook("quota", quota); err != nil {
+		t.Fatalf("Error registering hook. Err: %v", err)
+	}
+	defer UnregisterEndpointHook("quota")
+
+	if err := RegisterEndpointHook("quota", quota); err == nil {
+		t.Fatalf("duplicate hook registration succeeded")
+	}
+
+	req := &CreateEndpointRequest{TenantName: "default", EndpointID: "ep1", EPCommonName: "pod1"}
+	if err := admitEndpointCreate(req); err != nil {
+		t.Fatalf("endpoint create rejected. Err: %v", err)
+	}
+	if req.EPCommonName != "web-pod1" {
+		t.Fatalf("hook mutation not applied, got %q", req.EPCommonName)
+	}
+	if strings.Join(order, ",") != "rename,quota" {
+		t.Fatalf("hooks ran in wrong order: %v", order)
+	}
+
+	req = &CreateEndpointRequest{TenantName: "full", EndpointID: "ep2"}
+	err := admitEndpointCreate(req)
+	if err == nil || !strings.Contains(err.Error(), "rejected by quota") {
+		t.Fatalf("expected endpoint to be rejected by quota, got %v", err)
+	}
+
+	delReq := DeleteEndpointRequest{EndpointID: "ep1"}
+	admitEndpointDelete(delReq)
+	if rename.deletes != 1 || quota.deletes != 1 {
+		t.Fatalf("delete did not run through all hooks: %d, %d", rename.deletes, quota.deletes)
+	}
+	if delReq.EndpointID != "ep1" {
+		t.Fatalf("delete hook changed the endpoint being deleted: %+v", delReq)
+	}
+
+	UnregisterEndpointHook("quota")
+	if err := admitEndpointCreate(&CreateEndpointRequest{TenantName: "full"}); err != nil {
+		t.Fatalf("unregistered hook still ran. Err: %v", err)
+	}
+}
+
+func TestEndpointWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		whReq := EndpointWebhookRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&whReq); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if whReq.Operation == "delete" {
+			// attempts to redirect the delete to another endpoint are ignored
+			w.Write([]byte(`{"allowed":true,"deleteRequest":{"EndpointID":"other"}}`))
+			return
+		}
+
+		resp := EndpointWebhookResponse{Allowed: true}
+		switch {
+		case whReq.CreateRequest.NetworkName == "":
+			resp.Allowed = false
+			resp.Reason = "network name is required"
+		default:
+			mutated := *whReq.CreateRequest
+			mutated.ServiceName = "default-group"
+			mutated.NetworkName = "other-net"
+			resp.CreateRequest = &mutated
+		}
+		json.NewEncoder(w).Encode(&resp)
+	}))
+	defer server.Close()
+
+	hook := NewEndpointWebhook(server.URL, time.Second)
+
+	req := &CreateEndpointRequest{NetworkName: "net1", EndpointID: "ep1"}
+	if err := hook.CreateEndpoint(req); err != nil {
+		t.Fatalf("webhook rejected endpoint. Err: %v", err)
+	}
+	if req.ServiceName != "default-group" {
+		t.Fatalf("webhook mutation not applied: %+v", req)
+	}
+	if req.NetworkName != "net1" || req.EndpointID != "ep1" {
+		t.Fatalf("webhook changed the endpoint identity: %+v", req)
+	}
+
+	err := hook.CreateEndpoint(&CreateEndpointRequest{EndpointID: "ep2"})
+	if err == nil || !strings.Contains(err.Error(), "network name is required") {
+		t.Fatalf("expected webhook rejection, got %v", err)
+	}
+
+	delReq := &DeleteEndpointRequest{EndpointID: "ep1"}
+	if err := hook.DeleteEndpoint(delReq); err != nil {
+		t.Fatalf("webhook rejected delete. Err: %v", err)
+	}
+	if delReq.EndpointID != "ep1" {
+		t.Fatalf("webhook changed the endpoint being deleted: %+v", delReq)
+	}
+
+	server.Close()
+	if err := hook.CreateEndpoint(&CreateEndpointRequest{NetworkName: "net1"}); err == nil {
+		t.Fatalf("create succeeded with webhook down")
+	}
+}
@@ -330,6 +330,12 @@ func CreateEndpointHandler(w http.ResponseWriter, r *http.Request, vars map[stri
 	}
 
 	log.Infof("Received CreateEndpointRequest: %+v", epReq)
+
+	// run admission hooks before any resources are allocated
+	if err := admitEndpointCreate(&epReq); err != nil {
+		return nil, err
+	}
+
 	// Take a global lock for address allocation
 	addrMutex.Lock()
 	defer addrMutex.Unlock()
@@ -350,6 +356,11 @@ func CreateEndpointHandler(w http.ResponseWriter, r *http.Request, vars map[stri
 		return nil, err
 	}
 
+	// the quota is checked under the lock so that it sees all earlier endpoints
+	if err := checkEndpointQuota(&epReq, nwCfg); err != nil {
+		return nil, err
+	}
+
 	// Create the endpoint
 	epCfg, err := CreateEndpoint(stateDriver, nwCfg, &epReq)
 	if err != nil {
@@ -378,6 +389,7 @@ func DeleteEndpointHandler(w http.ResponseWriter, r *http.Request, vars map[stri
 	}
 
 	log.Infof("Received DeleteEndpointRequest: %+v", epdelReq)
+	admitEndpointDelete(epdelReq)
 
 	// Gte the state driver
 	stateDriver, err := utils.GetStateDriver()
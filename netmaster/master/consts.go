@@ -24,4 +24,6 @@ const (
 	GetServiceRESTEndpoint = "service"
 	//GetServicesRESTEndpoint is the REST endpoint to request info of all services
 	GetServicesRESTEndpoint = "services"
+	// GetQuotaRESTEndpoint is the REST endpoint to get endpoint quota usage
+	GetQuotaRESTEndpoint = "quota"
 )
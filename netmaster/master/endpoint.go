@@ -187,6 +187,8 @@ func CreateEndpoint(stateDriver core.StateDriver, nwCfg *mastercfg.CfgNetworkSta
 		return nil, err
 	}
 
+	quotaEndpointCreated(epCfg, nwCfg)
+
 	return epCfg, nil
 }
 
@@ -295,6 +297,8 @@ func DeleteEndpointID(stateDriver core.StateDriver, epID string) (*mastercfg.Cfg
 		return nil, err
 	}
 
+	quotaEndpointDeleted(epCfg.ID)
+
 	return epCfg, err
 }
 
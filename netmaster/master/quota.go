@@ -0,0 +1,306 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package master
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/contiv/netplugin/core"
+	"github.com/contiv/netplugin/netmaster/mastercfg"
+	"github.com/contiv/netplugin/utils"
+)
+
+// EndpointQuota limits the number of endpoints per tenant and per host.
+// A zero limit disables the corresponding check. Endpoints on infra networks
+// are not counted.
+type EndpointQuota struct {
+	MaxPerTenant int
+	MaxPerHost   int
+
+	lock     sync.Mutex
+	rejected map[string]uint64 // rejections keyed by "tenant:<name>" or "host:<name>"
+	usage    *endpointUsage    // nil until loaded from the state store
+}
+
+// EndpointQuotaStats has the configured limits, current usage and the number
+// of endpoints rejected for exceeding them
+type EndpointQuotaStats struct {
+	MaxPerTenant int               `json:"maxPerTenant"`
+	MaxPerHost   int               `json:"maxPerHost"`
+	TenantUsage  map[string]int    `json:"tenantUsage"`
+	HostUsage    map[string]int    `json:"hostUsage"`
+	Rejected     map[string]uint64 `json:"rejected"`
+}
+
+// endpointUsage counts endpoints per tenant and per host
+type endpointUsage struct {
+	tenants   map[string]int
+	hosts     map[string]int
+	endpoints map[string]countedEndpoint // counted endpoints by endpoint ID
+}
+
+type countedEndpoint struct {
+	tenant string
+	host   string
+}
+
+var epQuota *EndpointQuota
+
+// SetEndpointQuota configures the endpoint limits checked on every create
+func SetEndpointQuota(maxPerTenant, maxPerHost int) error {
+	if maxPerTenant < 0 || maxPerHost < 0 {
+		return core.Errorf("invalid endpoint quota %d per tenant, %d per host", maxPerTenant, maxPerHost)
+	}
+
+	epQuota = &EndpointQuota{
+		MaxPerTenant: maxPerTenant,
+		MaxPerHost:   maxPerHost,
+		rejected:     make(map[string]uint64),
+	}
+
+	return nil
+}
+
+// ResetEndpointQuotaUsage drops the endpoint counts, they are reloaded from
+// the state store on the next check. Called when netmaster becomes leader, as
+// endpoints may have changed while another netmaster was leading.
+func ResetEndpointQuotaUsage() {
+	if epQuota == nil {
+		return
+	}
+
+	epQuota.lock.Lock()
+	defer epQuota.lock.Unlock()
+
+	epQuota.usage = nil
+}
+
+// GetEndpointQuotaStats returns the endpoint quota and its current usage
+func GetEndpointQuotaStats() (*EndpointQuotaStats, error) {
+	stats := &EndpointQuotaStats{
+		TenantUsage: make(map[string]int),
+		HostUsage:   make(map[string]int),
+		Rejected:    make(map[string]uint64),
+	}
+
+	var usage *endpointUsage
+	var err error
+	if epQuota != nil {
+		epQuota.lock.Lock()
+		defer epQuota.lock.Unlock()
+
+		stats.MaxPerTenant = epQuota.MaxPerTenant
+		stats.MaxPerHost = epQuota.MaxPerHost
+		for k, v := range epQuota.rejected {
+			stats.Rejected[k] = v
+		}
+		usage, err = epQuota.getUsage()
+	} else {
+		usage, err = loadEndpointUsage()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range usage.tenants {
+		stats.TenantUsage[k] = v
+	}
+	for k, v := range usage.hosts {
+		stats.HostUsage[k] = v
+	}
+	return stats, nil
+}
+
+// loadEndpointUsage counts the endpoints in the state store
+func loadEndpointUsage() (*endpointUsage, error) {
+	stateDriver, err := utils.GetStateDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	readNw := &mastercfg.CfgNetworkState{}
+	readNw.StateDriver = stateDriver
+	nws, err := readNw.ReadAll()
+	if core.ErrIfKeyExists(err) != nil {
+		return nil, err
+	}
+	networks := make(map[string]*mastercfg.CfgNetworkState)
+	for _, s := range nws {
+		nw := s.(*mastercfg.CfgNetworkState)
+		networks[nw.ID] = nw
+	}
+
+	readEp := &mastercfg.CfgEndpointState{}
+	readEp.StateDriver = stateDriver
+	eps, err := readEp.ReadAll()
+	if core.ErrIfKeyExists(err) != nil {
+		return nil, err
+	}
+
+	usage := &endpointUsage{
+		tenants:   make(map[string]int),
+		hosts:     make(map[string]int),
+		endpoints: make(map[string]countedEndpoint),
+	}
+	for _, s := range eps {
+		ep := s.(*mastercfg.CfgEndpointState)
+
+		var tenant string
+		if nw, ok := networks[ep.NetID]; ok {
+			if nw.NwType == "infra" {
+				continue
+			}
+			tenant = nw.Tenant
+		} else if idx := strings.LastIndex(ep.NetID, "."); idx >= 0 {
+			// network ids are of the form <network>.<tenant>
+			tenant = ep.NetID[idx+1:]
+		}
+
+		usage.add(ep.ID, tenant, ep.HomingHost)
+	}
+
+	return usage, nil
+}
+
+func (u *endpointUsage) add(epID, tenant, host string) {
+	if _, ok := u.endpoints[epID]; ok {
+		return
+	}
+
+	u.endpoints[epID] = countedEndpoint{tenant: tenant, host: host}
+	if tenant != "" {
+		u.tenants[tenant]++
+	}
+	if host != "" {
+		u.hosts[host]++
+	}
+}
+
+func (u *endpointUsage) remove(epID string) {
+	ep, ok := u.endpoints[epID]
+	if !ok {
+		return
+	}
+
+	delete(u.endpoints, epID)
+	if ep.tenant != "" {
+		if u.tenants[ep.tenant]--; u.tenants[ep.tenant] <= 0 {
+			delete(u.tenants, ep.tenant)
+		}
+	}
+	if ep.host != "" {
+		if u.hosts[ep.host]--; u.hosts[ep.host] <= 0 {
+			delete(u.hosts, ep.host)
+		}
+	}
+}
+
+// getUsage returns the endpoint counts, loading them if needed. Must be
+// called with q.lock held.
+func (q *EndpointQuota) getUsage() (*endpointUsage, error) {
+	if q.usage == nil {
+		usage, err := loadEndpointUsage()
+		if err != nil {
+			return nil, err
+		}
+		q.usage = usage
+	}
+
+	return q.usage, nil
+}
+
+// quotaEndpointCreated counts an endpoint written to the state store
+func quotaEndpointCreated(epCfg *mastercfg.CfgEndpointState, nwCfg *mastercfg.CfgNetworkState) {
+	if epQuota == nil || nwCfg.NwType == "infra" {
+		return
+	}
+
+	epQuota.lock.Lock()
+	defer epQuota.lock.Unlock()
+
+	// not loaded yet, the endpoint is picked up when it is
+	if epQuota.usage != nil {
+		epQuota.usage.add(epCfg.ID, nwCfg.Tenant, epCfg.HomingHost)
+	}
+}
+
+// quotaEndpointDeleted stops counting an endpoint removed from the state store
+func quotaEndpointDeleted(epID string) {
+	if epQuota == nil {
+		return
+	}
+
+	epQuota.lock.Lock()
+	defer epQuota.lock.Unlock()
+
+	if epQuota.usage != nil {
+		epQuota.usage.remove(epID)
+	}
+}
+
+// checkEndpointQuota rejects the create if it would exceed the endpoint
+// quota. It must be called with addrMutex held, so that concurrent creates
+// cannot both slip under a limit.
+func checkEndpointQuota(req *CreateEndpointRequest, nwCfg *mastercfg.CfgNetworkState) error {
+	if epQuota == nil || nwCfg.NwType == "infra" {
+		return nil
+	}
+
+	if err := epQuota.check(req, nwCfg); err != nil {
+		log.Errorf("Endpoint %s rejected by quota. Err: %v", req.EndpointID, err)
+		return core.Errorf("endpoint %s rejected by quota: %v", req.EndpointID, err)
+	}
+
+	return nil
+}
+
+// check returns an error if req would exceed one of the limits
+func (q *EndpointQuota) check(req *CreateEndpointRequest, nwCfg *mastercfg.CfgNetworkState) error {
+	if q.MaxPerTenant == 0 && q.MaxPerHost == 0 {
+		return nil
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	usage, err := q.getUsage()
+	if err != nil {
+		return err
+	}
+
+	// an endpoint being recreated does not count against the quota
+	if _, ok := usage.endpoints[getEpName(nwCfg.ID, &req.ConfigEP)]; ok {
+		return nil
+	}
+
+	tenant := nwCfg.Tenant
+	if q.MaxPerTenant > 0 && usage.tenants[tenant] >= q.MaxPerTenant {
+		q.rejected["tenant:"+tenant]++
+		return core.Errorf("tenant %s has reached its limit of %d endpoints",
+			tenant, q.MaxPerTenant)
+	}
+
+	host := req.ConfigEP.Host
+	if q.MaxPerHost > 0 && host != "" && usage.hosts[host] >= q.MaxPerHost {
+		q.rejected["host:"+host]++
+		return core.Errorf("host %s has reached its limit of %d endpoints",
+			host, q.MaxPerHost)
+	}
+
+	return nil
+}
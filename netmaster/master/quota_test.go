@@ -0,0 +1,143 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package master
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/contiv/netplugin/netmaster/intent"
+	"github.com/contiv/netplugin/netmaster/mastercfg"
+)
+
+func writeTestNetwork(t *testing.T, name, tenant, nwType string) *mastercfg.CfgNetworkState {
+	nwCfg := &mastercfg.CfgNetworkState{
+		Tenant:      tenant,
+		NetworkName: name,
+		NwType:      nwType,
+	}
+	nwCfg.StateDriver = fakeDriver
+	nwCfg.ID = name + "." + tenant
+	if err := nwCfg.Write(); err != nil {
+		t.Fatalf("error writing network %s. Err: %v", nwCfg.ID, err)
+	}
+	return nwCfg
+}
+
+func writeTestEndpoint(t *testing.T, nwCfg *mastercfg.CfgNetworkState, container, host string) *mastercfg.CfgEndpointState {
+	epCfg := &mastercfg.CfgEndpointState{
+		NetID:      nwCfg.ID,
+		HomingHost: host,
+	}
+	epCfg.StateDriver = fakeDriver
+	epCfg.ID = getEpName(nwCfg.ID, &intent.ConfigEP{Container: container})
+	if err := epCfg.Write(); err != nil {
+		t.Fatalf("error writing endpoint %s. Err: %v", epCfg.ID, err)
+	}
+	return epCfg
+}
+
+func testEndpointRequest(nwCfg *mastercfg.CfgNetworkState, container, host string) *CreateEndpointRequest {
+	return &CreateEndpointRequest{
+		TenantName:  nwCfg.Tenant,
+		NetworkName: nwCfg.NetworkName,
+		EndpointID:  container,
+		ConfigEP:    intent.ConfigEP{Container: container, Host: host},
+	}
+}
+
+func TestEndpointQuota(t *testing.T) {
+	initFakeStateDriver(t)
+	defer deinitFakeStateDriver()
+
+	net1 := writeTestNetwork(t, "net1", "tenant1", "data")
+	net2 := writeTestNetwork(t, "net2", "tenant1", "data")
+	net3 := writeTestNetwork(t, "net1", "tenant2", "data")
+	infra := writeTestNetwork(t, "infra", "tenant1", "infra")
+
+	writeTestEndpoint(t, net1, "c1", "host1")
+	writeTestEndpoint(t, net2, "c2", "host2")
+	writeTestEndpoint(t, net3, "c3", "host1")
+	writeTestEndpoint(t, infra, "host1", "host1")
+
+	if err := SetEndpointQuota(2, 2); err != nil {
+		t.Fatalf("error setting endpoint quota. Err: %v", err)
+	}
+	defer func() { epQuota = nil }()
+
+	// tenant1 is full, its infra endpoint does not count
+	err := checkEndpointQuota(testEndpointRequest(net1, "c4", "host2"), net1)
+	if err == nil || !strings.Contains(err.Error(), "tenant tenant1 has reached its limit") {
+		t.Fatalf("expected tenant quota error, got %v", err)
+	}
+
+	// recreating an existing endpoint is allowed
+	if err := checkEndpointQuota(testEndpointRequest(net1, "c1", "host1"), net1); err != nil {
+		t.Fatalf("recreating an endpoint was rejected. Err: %v", err)
+	}
+
+	// infra endpoints are never limited, even in a full tenant on a full host
+	if err := checkEndpointQuota(testEndpointRequest(infra, "host2", "host1"), infra); err != nil {
+		t.Fatalf("infra endpoint was rejected. Err: %v", err)
+	}
+
+	// host1 is full
+	err = checkEndpointQuota(testEndpointRequest(net3, "c5", "host1"), net3)
+	if err == nil || !strings.Contains(err.Error(), "host host1 has reached its limit") {
+		t.Fatalf("expected host quota error, got %v", err)
+	}
+
+	if err := checkEndpointQuota(testEndpointRequest(net3, "c5", "host2"), net3); err != nil {
+		t.Fatalf("endpoint within quota was rejected. Err: %v", err)
+	}
+
+	// creates and deletes keep the counts up to date
+	c5 := writeTestEndpoint(t, net3, "c5", "host2")
+	quotaEndpointCreated(c5, net3)
+	err = checkEndpointQuota(testEndpointRequest(net3, "c6", "host3"), net3)
+	if err == nil || !strings.Contains(err.Error(), "tenant tenant2 has reached its limit") {
+		t.Fatalf("expected tenant quota error after create, got %v", err)
+	}
+
+	quotaEndpointDeleted(c5.ID)
+	if err := checkEndpointQuota(testEndpointRequest(net3, "c6", "host3"), net3); err != nil {
+		t.Fatalf("endpoint rejected after delete. Err: %v", err)
+	}
+
+	stats, err := GetEndpointQuotaStats()
+	if err != nil {
+		t.Fatalf("error getting quota stats. Err: %v", err)
+	}
+	if stats.TenantUsage["tenant1"] != 2 || stats.HostUsage["host1"] != 2 || stats.HostUsage["host2"] != 1 {
+		t.Fatalf("unexpected quota usage: %+v", stats)
+	}
+	if stats.Rejected["tenant:tenant1"] != 1 || stats.Rejected["host:host1"] != 1 ||
+		stats.Rejected["tenant:tenant2"] != 1 {
+		t.Fatalf("unexpected rejection counts: %+v", stats.Rejected)
+	}
+
+	// counts are reloaded from the state store after a reset
+	ResetEndpointQuotaUsage()
+	writeTestEndpoint(t, net3, "c6", "host3")
+	err = checkEndpointQuota(testEndpointRequest(net3, "c7", "host3"), net3)
+	if err == nil || !strings.Contains(err.Error(), "tenant tenant2 has reached its limit") {
+		t.Fatalf("expected tenant quota error after reload, got %v", err)
+	}
+
+	if err := SetEndpointQuota(-1, 0); err == nil {
+		t.Fatalf("negative quota was accepted")
+	}
+}
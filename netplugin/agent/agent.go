@@ -254,6 +254,16 @@ func (ag *Agent) serveRequests() {
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(stats)
 	})
+	s.HandleFunc("/cnilatency", func(w http.ResponseWriter, r *http.Request) {
+		latency, err := k8splugin.GetLatency()
+		if err != nil {
+			log.Errorf("Error fetching cni latency. Err: %v", err)
+			http.Error(w, "Error fetching cni latency", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(latency)
+	})
 	s.HandleFunc("/inspect/driver", func(w http.ResponseWriter, r *http.Request) {
 		driverState, err := ag.netPlugin.InspectState()
 		if err != nil {
@@ -244,6 +244,11 @@ func main() {
 		},
 	}
 
+	// catch configuration errors before any driver is touched
+	if err := pluginConfig.Validate(); err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	// Create a new agent
 	ag := agent.NewAgent(&pluginConfig)
 
@@ -0,0 +1,101 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/contiv/netplugin/core"
+	"github.com/contiv/netplugin/utils"
+	"github.com/vishvananda/netlink"
+)
+
+// plugin modes accepted by the agent
+var validPluginModes = []string{"docker", "swarm-mode", "kubernetes", "test"}
+
+// linkByName is a hook for tests to fake uplink lookups
+var linkByName = netlink.LinkByName
+
+// Validate checks the plugin configuration before any driver is initialized.
+// All problems found are reported together, each with a hint on how to fix it,
+// so that a bad command line fails at startup rather than deep inside a driver.
+func (c *Config) Validate() error {
+	var errs []string
+
+	addErr := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	switch c.Drivers.Network {
+	case utils.OvsNameStr, utils.VppNameStr:
+	default:
+		addErr("unknown network driver %q, use -net-driver %s|%s",
+			c.Drivers.Network, utils.OvsNameStr, utils.VppNameStr)
+	}
+
+	switch c.Drivers.State {
+	case utils.EtcdNameStr, utils.ConsulNameStr:
+	default:
+		addErr("unknown state store %q, use -cluster-store %s://<ip>:<port> or %s://<ip>:<port>",
+			c.Drivers.State, utils.EtcdNameStr, utils.ConsulNameStr)
+	}
+
+	inst := c.Instance
+	if inst.HostLabel == "" {
+		addErr("empty host-label, set -host-label or make sure the hostname is set")
+	}
+
+	if !isValidPluginMode(inst.PluginMode) {
+		addErr("unknown plugin mode %q, use -plugin-mode %s",
+			inst.PluginMode, strings.Join(validPluginModes, "|"))
+	}
+
+	if net.ParseIP(inst.CtrlIP) == nil {
+		addErr("invalid control ip %q, set -ctrl-ip to an address on this host", inst.CtrlIP)
+	}
+
+	if net.ParseIP(inst.VtepIP) == nil {
+		addErr("invalid vtep ip %q, set -vtep-ip to an address on this host", inst.VtepIP)
+	}
+
+	if inst.VxlanUDPPort <= 0 || inst.VxlanUDPPort > 0xffff {
+		addErr("invalid vxlan port %d, -vxlan-port must be in range 1-65535", inst.VxlanUDPPort)
+	}
+
+	for _, intf := range inst.UplinkIntf {
+		if _, err := linkByName(intf); err != nil {
+			addErr("uplink interface %q not found (%v), check -vlan-if", intf, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return core.Errorf("invalid netplugin configuration:\n\t%s", strings.Join(errs, "\n\t"))
+	}
+
+	return nil
+}
+
+func isValidPluginMode(mode string) bool {
+	for _, m := range validPluginModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+/***
+Copyright 2017 Cisco Systems Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/contiv/netplugin/core"
+	"github.com/vishvananda/netlink"
+)
+
+func validTestConfig() Config {
+	return Config{
+		Drivers: Drivers{
+			Network: "ovs",
+			State:   "etcd",
+		},
+		Instance: core.InstanceInfo{
+			HostLabel:    "testHost",
+			CtrlIP:       "10.1.1.1",
+			VtepIP:       "10.1.1.1",
+			UplinkIntf:   []string{"eth2"},
+			PluginMode:   "docker",
+			VxlanUDPPort: 4789,
+		},
+	}
+}
+
+func fakeLinks(names ...string) func() {
+	orig := linkByName
+	linkByName = func(name string) (netlink.Link, error) {
+		for _, n := range names {
+			if n == name {
+				return &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: name}}, nil
+			}
+		}
+		return nil, errors.New("Link not found")
+	}
+
+	return func() { linkByName = orig }
+}
+
+func TestConfigValidate(t *testing.T) {
+	defer fakeLinks("eth2")()
+
+	cfg := validTestConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid config failed validation. Err: %v", err)
+	}
+}
+
+func TestConfigValidateErrors(t *testing.T) {
+	defer fakeLinks("eth2")()
+
+	testCases := []struct {
+		mutate func(*Config)
+		errStr string
+	}{
+		{func(c *Config) { c.Drivers.Network = "linuxbridge" }, "-net-driver"},
+		{func(c *Config) { c.Drivers.State = "zookeeper" }, "-cluster-store"},
+		{func(c *Config) { c.Instance.HostLabel = "" }, "-host-label"},
+		{func(c *Config) { c.Instance.PluginMode = "mesos" }, "-plugin-mode"},
+		{func(c *Config) { c.Instance.CtrlIP = "10.1.1" }, "-ctrl-ip"},
+		{func(c *Config) { c.Instance.VtepIP = "" }, "-vtep-ip"},
+		{func(c *Config) { c.Instance.VxlanUDPPort = 70000 }, "-vxlan-port"},
+		{func(c *Config) { c.Instance.UplinkIntf = []string{"eth3"} }, "-vlan-if"},
+	}
+
+	for _, tc := range testCases {
+		cfg := validTestConfig()
+		tc.mutate(&cfg)
+		err := cfg.Validate()
+		if err == nil {
+			t.Fatalf("config %+v passed validation, expected error mentioning %s", cfg, tc.errStr)
+		}
+		if !strings.Contains(err.Error(), tc.errStr) {
+			t.Fatalf("error %q does not mention %s", err, tc.errStr)
+		}
+	}
+}
+
+func TestConfigValidateReportsAllErrors(t *testing.T) {
+	defer fakeLinks()()
+
+	cfg := validTestConfig()
+	cfg.Instance.CtrlIP = ""
+	cfg.Instance.VxlanUDPPort = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("invalid config passed validation")
+	}
+
+	for _, s := range []string{"-ctrl-ip", "-vxlan-port", "-vlan-if"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Fatalf("error %q does not mention %s", err, s)
+		}
+	}
+}
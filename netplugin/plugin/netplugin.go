@@ -323,17 +323,17 @@ func InitGlobalSettings(stateDriver core.StateDriver, inst *core.InstanceInfo) {
 }
 
 //AddSvcSpec adds k8 service spec
-func (p *NetPlugin) AddSvcSpec(svcName string, spec *core.ServiceSpec) {
+func (p *NetPlugin) AddSvcSpec(svcName string, spec *core.ServiceSpec) error {
 	p.Lock()
 	defer p.Unlock()
-	p.NetworkDriver.AddSvcSpec(svcName, spec)
+	return p.NetworkDriver.AddSvcSpec(svcName, spec)
 }
 
 //DelSvcSpec deletes k8 service spec
-func (p *NetPlugin) DelSvcSpec(svcName string, spec *core.ServiceSpec) {
+func (p *NetPlugin) DelSvcSpec(svcName string, spec *core.ServiceSpec) error {
 	p.Lock()
 	defer p.Unlock()
-	p.NetworkDriver.DelSvcSpec(svcName, spec)
+	return p.NetworkDriver.DelSvcSpec(svcName, spec)
 }
 
 // AddPolicyRule creates a policy rule
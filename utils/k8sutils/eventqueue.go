@@ -0,0 +1,186 @@
+package k8sutils
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond // delay before the first retry
+	retryMaxDelay  = time.Minute            // cap on the retry delay
+)
+
+// Event is an update of a k8s object waiting to be processed
+type Event struct {
+	Key string      // identifies the object, events with the same key are coalesced
+	Obj interface{} // whatever the watcher needs to process the update
+
+	gen uint64 // generation of Key when the event was added
+}
+
+type pendingEvent struct {
+	event Event
+	first time.Time   // when the first event of the burst was seen
+	timer *time.Timer // fires when the burst is over
+}
+
+// EventQueue coalesces bursts of events for the same key. Once no event for
+// a key has been seen for the window, or the key has been pending for
+// maxDelay, only the latest event is handed out by Get. Events that failed
+// to be processed are put back with Requeue and handed out again after an
+// exponential backoff.
+//
+// Every Add bumps the generation of its key. An event older than the latest
+// one added for its key is never handed out or retried, so updates to the
+// same object are always applied in order.
+type EventQueue struct {
+	window   time.Duration
+	maxDelay time.Duration
+	ready    chan Event
+
+	retryBase time.Duration
+	retryMax  time.Duration
+
+	mutex    sync.Mutex
+	pending  map[string]*pendingEvent
+	gens     map[string]uint64 // latest generation per key
+	queued   map[string]int    // events per key sitting in ready
+	failures map[string]uint   // consecutive failures per key
+}
+
+// NewEventQueue returns a queue batching events over window. Zero disables
+// batching, events are then only retried.
+func NewEventQueue(window time.Duration) *EventQueue {
+	return &EventQueue{
+		window:    window,
+		maxDelay:  10 * window,
+		ready:     make(chan Event, 100),
+		retryBase: retryBaseDelay,
+		retryMax:  retryMaxDelay,
+		pending:   make(map[string]*pendingEvent),
+		gens:      make(map[string]uint64),
+		queued:    make(map[string]int),
+		failures:  make(map[string]uint),
+	}
+}
+
+// Add queues obj for key, replacing any event pending for it
+func (q *EventQueue) Add(key string, obj interface{}) {
+	q.mutex.Lock()
+
+	q.gens[key]++
+	ev := Event{Key: key, Obj: obj, gen: q.gens[key]}
+
+	if q.window <= 0 {
+		q.queued[key]++
+		q.mutex.Unlock()
+		q.ready <- ev
+		return
+	}
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+	p, ok := q.pending[key]
+	if !ok {
+		p = &pendingEvent{first: now}
+		p.timer = time.AfterFunc(q.window, func() { q.flush(key, p) })
+		q.pending[key] = p
+	} else {
+		// keep extending the window, but never past maxDelay
+		wait := q.window
+		if left := q.maxDelay - now.Sub(p.first); left < wait {
+			wait = left
+		}
+		p.timer.Reset(wait)
+	}
+
+	p.event = ev
+}
+
+// Get blocks until an event is ready, skipping events that a newer one for
+// the same key has replaced in the meantime
+func (q *EventQueue) Get() Event {
+	for {
+		ev := <-q.ready
+		if !q.dequeue(ev) {
+			return ev
+		}
+	}
+}
+
+// Requeue hands ev out again after a delay that doubles with every
+// consecutive failure of its key, and returns that delay. It returns false
+// and drops ev if a newer event for the key has been added since.
+func (q *EventQueue) Requeue(ev Event) (time.Duration, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if ev.gen < q.gens[ev.Key] {
+		return 0, false
+	}
+
+	delay := q.retryBase << q.failures[ev.Key]
+	if delay <= 0 || delay > q.retryMax {
+		delay = q.retryMax
+	} else {
+		q.failures[ev.Key]++
+	}
+
+	// backdate first so that a newer event arriving during the backoff is
+	// held for at most the window, not the rest of the backoff
+	p := &pendingEvent{event: ev, first: time.Now().Add(delay - q.maxDelay)}
+	p.timer = time.AfterFunc(delay, func() { q.flush(ev.Key, p) })
+	q.pending[ev.Key] = p
+
+	return delay, true
+}
+
+// Forget resets the backoff of the key of ev after it was processed
+// successfully
+func (q *EventQueue) Forget(ev Event) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.failures, ev.Key)
+
+	// with nothing else pending or queued for the key, no older event can
+	// show up any more and its generation can start over
+	_, pending := q.pending[ev.Key]
+	if !pending && q.queued[ev.Key] == 0 && ev.gen == q.gens[ev.Key] {
+		delete(q.gens, ev.Key)
+	}
+}
+
+// dequeue accounts for ev having been taken off ready and reports whether
+// it is stale
+func (q *EventQueue) dequeue(ev Event) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.queued[ev.Key]--; q.queued[ev.Key] <= 0 {
+		delete(q.queued, ev.Key)
+	}
+
+	return ev.gen < q.gens[ev.Key]
+}
+
+// flush hands out the event pending for key. A timer reset after it had
+// already fired may call flush again, by then p is no longer pending.
+func (q *EventQueue) flush(key string, p *pendingEvent) {
+	q.mutex.Lock()
+	if q.pending[key] != p {
+		q.mutex.Unlock()
+		return
+	}
+	delete(q.pending, key)
+	ev := p.event
+	stale := ev.gen < q.gens[key]
+	if !stale {
+		q.queued[key]++
+	}
+	q.mutex.Unlock()
+
+	if !stale {
+		q.ready <- ev
+	}
+}
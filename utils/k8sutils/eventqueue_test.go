@@ -0,0 +1,176 @@
+package k8sutils
+
+import (
+	"testing"
+	"time"
+)
+
+func recvEvent(t *testing.T, q *EventQueue, timeout time.Duration) Event {
+	ch := make(chan Event, 1)
+	go func() { ch <- q.Get() }()
+
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(timeout):
+		t.Fatalf("no event received in %v", timeout)
+	}
+	return Event{}
+}
+
+// expectNoEvent fails if anything but stale events shows up within wait
+func expectNoEvent(t *testing.T, q *EventQueue, wait time.Duration) {
+	time.Sleep(wait)
+	for len(q.ready) > 0 {
+		if ev := <-q.ready; !q.dequeue(ev) {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+}
+
+func TestEventQueueCoalesces(t *testing.T) {
+	q := NewEventQueue(50 * time.Millisecond)
+
+	q.Add("namespace/ns1", 1)
+	q.Add("namespace/ns1", 2)
+	q.Add("namespace/ns1", 3)
+	q.Add("namespace/ns2", 4)
+
+	got := map[string]interface{}{}
+	for i := 0; i < 2; i++ {
+		ev := recvEvent(t, q, time.Second)
+		got[ev.Key] = ev.Obj
+	}
+
+	if len(got) != 2 || got["namespace/ns1"] != 3 || got["namespace/ns2"] != 4 {
+		t.Fatalf("expected latest event per key, got %v", got)
+	}
+	expectNoEvent(t, q, 100*time.Millisecond)
+}
+
+func TestEventQueueMaxDelay(t *testing.T) {
+	q := NewEventQueue(40 * time.Millisecond)
+	q.maxDelay = 150 * time.Millisecond
+
+	// keep updating faster than the window, the key must still be flushed
+	start := time.Now()
+	stop := time.After(400 * time.Millisecond)
+	flushed := false
+	for !flushed {
+		select {
+		case <-stop:
+			t.Fatalf("continuously updated key was never flushed")
+		case <-q.ready:
+			flushed = true
+		case <-time.After(10 * time.Millisecond):
+			q.Add("namespace/busy", nil)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < q.maxDelay {
+		t.Fatalf("key flushed after %v, before max delay %v", elapsed, q.maxDelay)
+	}
+}
+
+func TestEventQueueNoBatching(t *testing.T) {
+	q := NewEventQueue(0)
+
+	q.Add("namespace/ns1", 1)
+	q.Add("namespace/ns2", 2)
+
+	if ev := recvEvent(t, q, time.Second); ev.Obj != 1 {
+		t.Fatalf("expected first event, got %+v", ev)
+	}
+	if ev := recvEvent(t, q, time.Second); ev.Obj != 2 {
+		t.Fatalf("expected second event, got %+v", ev)
+	}
+}
+
+func TestEventQueueRequeueBackoff(t *testing.T) {
+	q := NewEventQueue(10 * time.Millisecond)
+	q.retryBase = 20 * time.Millisecond
+	q.retryMax = 50 * time.Millisecond
+
+	q.Add("namespace/ns1", 1)
+	ev := recvEvent(t, q, time.Second)
+	for _, want := range []time.Duration{20, 40, 50, 50} {
+		delay, ok := q.Requeue(ev)
+		if !ok || delay != want*time.Millisecond {
+			t.Fatalf("expected retry delay %v, got %v", want*time.Millisecond, delay)
+		}
+		if ev = recvEvent(t, q, time.Second); ev.Obj != 1 {
+			t.Fatalf("expected requeued event, got %+v", ev)
+		}
+	}
+
+	q.Forget(ev)
+	if delay, _ := q.Requeue(ev); delay != q.retryBase {
+		t.Fatalf("backoff not reset after forget, got %v", delay)
+	}
+	recvEvent(t, q, time.Second)
+}
+
+func TestEventQueueRequeueReplacedByNewerEvent(t *testing.T) {
+	q := NewEventQueue(10 * time.Millisecond)
+	q.retryBase = time.Second
+
+	q.Add("namespace/ns1", 1)
+	ev := recvEvent(t, q, time.Second)
+	q.Requeue(ev)
+	q.Add("namespace/ns1", 2)
+
+	// the newer event is handed out after the window, not the retry delay
+	if ev := recvEvent(t, q, 200*time.Millisecond); ev.Obj != 2 {
+		t.Fatalf("expected newer event, got %+v", ev)
+	}
+	expectNoEvent(t, q, 50*time.Millisecond)
+}
+
+func TestEventQueueRequeueAfterNewerEventFlushed(t *testing.T) {
+	q := NewEventQueue(10 * time.Millisecond)
+	q.retryBase = 20 * time.Millisecond
+
+	q.Add("namespace/ns1", 1)
+	failed := recvEvent(t, q, time.Second)
+
+	// a newer event is flushed while the first one is still being processed
+	q.Add("namespace/ns1", 2)
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := q.Requeue(failed); ok {
+		t.Fatalf("failed event was requeued behind a newer one")
+	}
+	if ev := recvEvent(t, q, time.Second); ev.Obj != 2 {
+		t.Fatalf("expected newer event, got %+v", ev)
+	}
+	expectNoEvent(t, q, 100*time.Millisecond)
+}
+
+func TestEventQueueRequeueNoBatching(t *testing.T) {
+	q := NewEventQueue(0)
+	q.retryBase = 20 * time.Millisecond
+
+	q.Add("namespace/ns1", 1)
+	failed := recvEvent(t, q, time.Second)
+	q.Add("namespace/ns1", 2)
+
+	if _, ok := q.Requeue(failed); ok {
+		t.Fatalf("failed event was requeued behind a newer one")
+	}
+	if ev := recvEvent(t, q, time.Second); ev.Obj != 2 {
+		t.Fatalf("expected newer event, got %+v", ev)
+	}
+	expectNoEvent(t, q, 100*time.Millisecond)
+
+	// a retry scheduled before a newer event is added is not handed out
+	q.Add("namespace/ns1", 3)
+	failed = recvEvent(t, q, time.Second)
+	if _, ok := q.Requeue(failed); !ok {
+		t.Fatalf("current event was not requeued")
+	}
+	q.Add("namespace/ns1", 4)
+	if ev := recvEvent(t, q, time.Second); ev.Obj != 4 {
+		t.Fatalf("expected newer event, got %+v", ev)
+	}
+	expectNoEvent(t, q, 100*time.Millisecond)
+}